@@ -0,0 +1,182 @@
+package driver
+
+import (
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/chrislusf/glow/flow"
+)
+
+// DefaultTimeWindowSize is the batching interval flow.TimeWindowMerge falls
+// back to when a Dataset leaves WindowSize at its zero value.
+const DefaultTimeWindowSize = 100 * time.Millisecond
+
+// mergeExternalInputChans fuses several typed external input channels into
+// a single channel according to mode, so that flow graphs with more than
+// one external input can express a join natively instead of requiring an
+// extra shuffle step downstream. windowSize only applies to
+// flow.TimeWindowMerge; pass 0 to get DefaultTimeWindowSize.
+func mergeExternalInputChans(mode flow.ChannelMergeMode, keyFn flow.KeyFn, windowSize time.Duration, inputs []chan reflect.Value) chan reflect.Value {
+	out := make(chan reflect.Value)
+	switch mode {
+	case flow.RoundRobin:
+		go mergeRoundRobin(inputs, out)
+	case flow.ZipByKey:
+		go mergeZipByKey(keyFn, inputs, out)
+	case flow.TimeWindowMerge:
+		if windowSize <= 0 {
+			windowSize = DefaultTimeWindowSize
+		}
+		go mergeTimeWindow(inputs, windowSize, out)
+	default:
+		go mergeInterleave(inputs, out)
+	}
+	return out
+}
+
+func mergeInterleave(inputs []chan reflect.Value, out chan reflect.Value) {
+	var wg sync.WaitGroup
+	for _, in := range inputs {
+		wg.Add(1)
+		go func(in chan reflect.Value) {
+			defer wg.Done()
+			for v := range in {
+				out <- v
+			}
+		}(in)
+	}
+	wg.Wait()
+	close(out)
+}
+
+func mergeRoundRobin(inputs []chan reflect.Value, out chan reflect.Value) {
+	open := make([]bool, len(inputs))
+	for i := range open {
+		open[i] = true
+	}
+	remaining := len(inputs)
+	for remaining > 0 {
+		for i, in := range inputs {
+			if !open[i] {
+				continue
+			}
+			v, ok := <-in
+			if !ok {
+				open[i] = false
+				remaining--
+				continue
+			}
+			out <- v
+		}
+	}
+	close(out)
+}
+
+// mergeZipByKey buffers one element per input keyed by keyFn, and emits a
+// combined []interface{} tuple (one slot per input, in input order, holding
+// each input's own domain value - not a reflect.Value) once every input has
+// produced a value for that key. Completeness is decided by scanning every
+// slot for IsValid(), not by counting arrivals: an input that emits the
+// same key twice before the others catch up only overwrites its own slot,
+// so a naive per-key counter would reach "every input has arrived" while
+// other slots are still zero Values and panic on Interface().
+func mergeZipByKey(keyFn flow.KeyFn, inputs []chan reflect.Value, out chan reflect.Value) {
+	pending := make(map[interface{}][]reflect.Value)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for i, in := range inputs {
+		wg.Add(1)
+		go func(slot int, in chan reflect.Value) {
+			defer wg.Done()
+			for v := range in {
+				key := keyFn(v.Interface())
+
+				mu.Lock()
+				tuple, ok := pending[key]
+				if !ok {
+					tuple = make([]reflect.Value, len(inputs))
+					pending[key] = tuple
+				}
+				tuple[slot] = v
+				complete := true
+				for _, slotValue := range tuple {
+					if !slotValue.IsValid() {
+						complete = false
+						break
+					}
+				}
+				if complete {
+					delete(pending, key)
+				}
+				mu.Unlock()
+
+				if complete {
+					domainTuple := make([]interface{}, len(tuple))
+					for i, slotValue := range tuple {
+						domainTuple[i] = slotValue.Interface()
+					}
+					out <- reflect.ValueOf(domainTuple)
+				}
+			}
+		}(i, in)
+	}
+
+	wg.Wait()
+	close(out)
+}
+
+type timestampedValue struct {
+	arrival time.Time
+	value   reflect.Value
+}
+
+// mergeTimeWindow tags each value with its arrival time and emits batches,
+// sorted by arrival time, every windowSize.
+func mergeTimeWindow(inputs []chan reflect.Value, windowSize time.Duration, out chan reflect.Value) {
+	tagged := make(chan timestampedValue)
+	var wg sync.WaitGroup
+	for _, in := range inputs {
+		wg.Add(1)
+		go func(in chan reflect.Value) {
+			defer wg.Done()
+			for v := range in {
+				tagged <- timestampedValue{arrival: time.Now(), value: v}
+			}
+		}(in)
+	}
+	go func() {
+		wg.Wait()
+		close(tagged)
+	}()
+
+	ticker := time.NewTicker(windowSize)
+	defer ticker.Stop()
+	var batch []timestampedValue
+	for {
+		select {
+		case tv, ok := <-tagged:
+			if !ok {
+				flushBatch(batch, out)
+				close(out)
+				return
+			}
+			batch = append(batch, tv)
+		case <-ticker.C:
+			batch = flushBatch(batch, out)
+		}
+	}
+}
+
+func flushBatch(batch []timestampedValue, out chan reflect.Value) []timestampedValue {
+	if len(batch) == 0 {
+		return batch
+	}
+	sort.Slice(batch, func(i, j int) bool { return batch[i].arrival.Before(batch[j].arrival) })
+	for _, tv := range batch {
+		out <- tv.value
+	}
+	return batch[:0]
+}
@@ -0,0 +1,194 @@
+package driver
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/chrislusf/glow/flow"
+)
+
+// TestMergeInterleave checks that the default NoMerge-adjacent Interleave
+// mode forwards every value from every input, without requiring a key or
+// dropping anything as the inputs close at different times. The order
+// across inputs is not checked.
+func TestMergeInterleave(t *testing.T) {
+	a := make(chan reflect.Value, 2)
+	b := make(chan reflect.Value, 2)
+	a <- reflect.ValueOf(1)
+	a <- reflect.ValueOf(2)
+	b <- reflect.ValueOf(3)
+	close(a)
+	close(b)
+
+	out := mergeExternalInputChans(flow.Interleave, nil, 0, []chan reflect.Value{a, b})
+
+	var got []int
+	for v := range out {
+		got = append(got, int(v.Int()))
+	}
+
+	if len(got) != 3 {
+		t.Fatalf("mergeInterleave emitted %v, want 3 values", got)
+	}
+	seen := make(map[int]bool)
+	for _, v := range got {
+		seen[v] = true
+	}
+	for _, want := range []int{1, 2, 3} {
+		if !seen[want] {
+			t.Fatalf("mergeInterleave %v missing value %d", got, want)
+		}
+	}
+}
+
+// TestMergeTimeWindowUsesConfiguredWindowSize checks that TimeWindowMerge
+// honors a Dataset's configured window instead of the hardcoded 100ms
+// default: the input is left open past the window (only the ticker, not
+// input closure, can trigger the flush), so a too-short wait would prove
+// the window was ignored.
+func TestMergeTimeWindowUsesConfiguredWindowSize(t *testing.T) {
+	a := make(chan reflect.Value, 1)
+	a <- reflect.ValueOf(1)
+
+	start := time.Now()
+	out := mergeExternalInputChans(flow.TimeWindowMerge, nil, 150*time.Millisecond, []chan reflect.Value{a})
+
+	v, ok := <-out
+	if !ok {
+		t.Fatalf("mergeTimeWindow closed out before emitting a value")
+	}
+	if elapsed := time.Since(start); elapsed < 150*time.Millisecond {
+		t.Fatalf("mergeTimeWindow flushed after %v, want it to honor the configured 150ms window", elapsed)
+	}
+	if got := int(v.Int()); got != 1 {
+		t.Fatalf("mergeTimeWindow emitted %d, want 1", got)
+	}
+
+	close(a)
+	if _, ok := <-out; ok {
+		t.Fatalf("mergeTimeWindow emitted more than one value")
+	}
+}
+
+// TestMergeTimeWindowDefaultsWindowSize checks that a zero windowSize (a
+// Dataset that doesn't set WindowSize) still flushes, using
+// DefaultTimeWindowSize, instead of never firing the ticker.
+func TestMergeTimeWindowDefaultsWindowSize(t *testing.T) {
+	a := make(chan reflect.Value, 1)
+	a <- reflect.ValueOf(7)
+	defer close(a)
+
+	out := mergeExternalInputChans(flow.TimeWindowMerge, nil, 0, []chan reflect.Value{a})
+
+	select {
+	case v, ok := <-out:
+		if !ok {
+			t.Fatalf("mergeTimeWindow closed out before emitting a value")
+		}
+		if got := int(v.Int()); got != 7 {
+			t.Fatalf("mergeTimeWindow emitted %d, want 7", got)
+		}
+	case <-time.After(2 * DefaultTimeWindowSize):
+		t.Fatalf("mergeTimeWindow never flushed within 2x DefaultTimeWindowSize")
+	}
+}
+
+func TestMergeRoundRobin(t *testing.T) {
+	a := make(chan reflect.Value, 2)
+	b := make(chan reflect.Value, 2)
+	a <- reflect.ValueOf(1)
+	a <- reflect.ValueOf(3)
+	b <- reflect.ValueOf(2)
+	b <- reflect.ValueOf(4)
+	close(a)
+	close(b)
+
+	out := mergeExternalInputChans(flow.RoundRobin, nil, 0, []chan reflect.Value{a, b})
+
+	var got []int
+	for v := range out {
+		got = append(got, int(v.Int()))
+	}
+
+	want := []int{1, 2, 3, 4}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("mergeRoundRobin = %v, want %v", got, want)
+	}
+}
+
+func TestMergeZipByKey(t *testing.T) {
+	type keyed struct {
+		Key   int
+		Value string
+	}
+	keyFn := func(v interface{}) interface{} {
+		return v.(keyed).Key
+	}
+
+	a := make(chan reflect.Value, 2)
+	b := make(chan reflect.Value, 2)
+	a <- reflect.ValueOf(keyed{Key: 1, Value: "a1"})
+	a <- reflect.ValueOf(keyed{Key: 2, Value: "a2"})
+	b <- reflect.ValueOf(keyed{Key: 1, Value: "b1"})
+	b <- reflect.ValueOf(keyed{Key: 2, Value: "b2"})
+	close(a)
+	close(b)
+
+	out := mergeExternalInputChans(flow.ZipByKey, keyFn, 0, []chan reflect.Value{a, b})
+
+	seenKeys := make(map[int]bool)
+	for v := range out {
+		tuple, ok := v.Interface().([]interface{})
+		if !ok {
+			t.Fatalf("mergeZipByKey emitted %T, want []interface{}", v.Interface())
+		}
+		if len(tuple) != 2 {
+			t.Fatalf("tuple length = %d, want 2", len(tuple))
+		}
+		first := tuple[0].(keyed)
+		second := tuple[1].(keyed)
+		if first.Key != second.Key {
+			t.Fatalf("zipped tuple keys don't match: %v vs %v", first, second)
+		}
+		seenKeys[first.Key] = true
+	}
+
+	if !seenKeys[1] || !seenKeys[2] {
+		t.Fatalf("expected tuples for keys 1 and 2, got %v", seenKeys)
+	}
+}
+
+// TestMergeZipByKeyDuplicateKeyDoesNotPanic guards against re-introducing a
+// per-key arrival counter in place of the IsValid() completeness scan: one
+// input emitting the same key twice before the other input catches up must
+// not make mergeZipByKey think the tuple is complete while the other slot
+// is still a zero Value.
+func TestMergeZipByKeyDuplicateKeyDoesNotPanic(t *testing.T) {
+	type keyed struct {
+		Key   int
+		Value string
+	}
+	keyFn := func(v interface{}) interface{} {
+		return v.(keyed).Key
+	}
+
+	a := make(chan reflect.Value, 2)
+	b := make(chan reflect.Value, 1)
+	a <- reflect.ValueOf(keyed{Key: 1, Value: "a1"})
+	a <- reflect.ValueOf(keyed{Key: 1, Value: "a1-again"})
+	b <- reflect.ValueOf(keyed{Key: 1, Value: "b1"})
+	close(a)
+	close(b)
+
+	out := mergeExternalInputChans(flow.ZipByKey, keyFn, 0, []chan reflect.Value{a, b})
+
+	var tuples [][]interface{}
+	for v := range out {
+		tuples = append(tuples, v.Interface().([]interface{}))
+	}
+
+	if len(tuples) != 1 {
+		t.Fatalf("got %d tuples, want 1: %v", len(tuples), tuples)
+	}
+}
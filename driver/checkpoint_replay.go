@@ -0,0 +1,103 @@
+package driver
+
+import (
+	"encoding/gob"
+	"errors"
+	"io"
+	"reflect"
+	"sync"
+
+	"github.com/chrislusf/glow/flow"
+)
+
+// checkpointEncoder spills every forwarded item for one shard boundary to
+// this attempt's CheckpointStore entry, so a later attempt can resume from
+// it instead of recomputing the shard from scratch.
+type checkpointEncoder struct {
+	w   io.WriteCloser
+	enc *gob.Encoder
+}
+
+func (c *checkpointEncoder) Encode(v interface{}) error {
+	return c.enc.Encode(v)
+}
+
+func (c *checkpointEncoder) Close() error {
+	return c.w.Close()
+}
+
+// checkpointWriter opens this attempt's checkpoint entry for currentShard,
+// or returns nil if no CheckpointStore is configured.
+func (tr *TaskRunner) checkpointWriter(shardName string) *checkpointEncoder {
+	store := tr.option.CheckpointStore
+	if store == nil {
+		return nil
+	}
+	w, err := store.Writer(tr.option.TaskGroupId, tr.attemptId, shardName)
+	if err != nil {
+		tr.logger().Error(tr.fields("checkpoint_write", shardName), err)
+		return nil
+	}
+	return &checkpointEncoder{w: w, enc: gob.NewEncoder(w)}
+}
+
+// resumeIndex returns the highest adjacent-shard-pair index whose output is
+// fully checkpointed from the previous attempt, or -1 if none is (including
+// when there's no CheckpointStore configured, or this is the first
+// attempt). Tasks 0..resumeIndex must not be re-run: resumeFromCheckpoint
+// replays resumeIndex's boundary straight into the next task's input, so if
+// an earlier task ran anyway it would block forever writing to a shard
+// nothing drains.
+func (tr *TaskRunner) resumeIndex() int {
+	store := tr.option.CheckpointStore
+	if store == nil || tr.attemptId == 0 {
+		return -1
+	}
+	prevAttempt := tr.attemptId - 1
+	resumeAt := -1
+	for i := 0; i < len(tr.Tasks)-1; i++ {
+		shardName := tr.Tasks[i].Outputs[0].Name()
+		if store.Has(tr.option.TaskGroupId, prevAttempt, shardName) {
+			resumeAt = i
+		}
+	}
+	return resumeAt
+}
+
+// resumeFromCheckpoint replays currentShard's output from the previous
+// attempt's checkpoint instead of recomputing it. Callers only invoke this
+// for the shard pair at tr.resumeAt, which resumeIndex has already
+// confirmed has a checkpoint - the normal ForwarderPool-driven forwarding
+// goroutine, and the task that would have produced currentShard, must not
+// also run for this pair.
+func (tr *TaskRunner) resumeFromCheckpoint(wg *sync.WaitGroup, currentShard, nextShard *flow.DatasetShard) {
+	store := tr.option.CheckpointStore
+	shardName := currentShard.Name()
+	r, err := store.Reader(tr.option.TaskGroupId, tr.attemptId-1, shardName)
+	if err != nil {
+		tr.logger().Error(tr.fields("checkpoint_replay", shardName), err)
+		nextShard.CloseRead()
+		return
+	}
+
+	elemType := currentShard.Parent.Type
+	dec := gob.NewDecoder(r)
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer r.Close()
+		for {
+			ptr := reflect.New(elemType)
+			if err := dec.Decode(ptr.Interface()); err != nil {
+				if !errors.Is(err, io.EOF) {
+					tr.logger().Error(tr.fields("checkpoint_replay", shardName), err)
+				}
+				break
+			}
+			nextShard.SendForRead(ptr.Elem())
+			tr.executorStatus.recordProgress()
+		}
+		nextShard.CloseRead()
+	}()
+}
@@ -0,0 +1,94 @@
+package driver
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestExecutorStatusConcurrentAccess exercises every progressMu-guarded
+// field under concurrent readers and writers, the way Supervisor.Poll and
+// an inter-task forwarder goroutine actually touch them - run with
+// -race, this is what would have caught the unguarded StartTime/StopTime
+// access.
+func TestExecutorStatusConcurrentAccess(t *testing.T) {
+	es := &ExecutorStatus{}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		es.setStartTime(time.Now())
+		for i := 0; i < 100; i++ {
+			es.recordProgress()
+			es.addForwardWait(time.Millisecond)
+			es.addBytesForwarded(1)
+		}
+		es.setStopTime(time.Now())
+	}()
+
+	for i := 0; i < 100; i++ {
+		_ = es.StartTime()
+		_ = es.StopTime()
+		_ = es.LastProgressTime()
+		_ = es.ForwardWaitTime()
+		_ = es.BytesForwarded()
+	}
+	wg.Wait()
+
+	if es.StartTime().IsZero() {
+		t.Fatalf("StartTime should be set after setStartTime")
+	}
+	if es.StopTime().IsZero() {
+		t.Fatalf("StopTime should be set after setStopTime")
+	}
+	if got := es.ForwardWaitTime(); got != 100*time.Millisecond {
+		t.Fatalf("ForwardWaitTime = %v, want %v", got, 100*time.Millisecond)
+	}
+	if got := es.BytesForwarded(); got != 100 {
+		t.Fatalf("BytesForwarded = %d, want 100", got)
+	}
+}
+
+// TestExecutorStatusSnapshotExposesGuardedFields checks that Snapshot
+// surfaces the progressMu-guarded fields as plain exported struct fields,
+// so a caller marshaling the snapshot (e.g. to JSON for a status page)
+// doesn't silently lose start/stop/progress/wait/byte data the way
+// marshaling *ExecutorStatus directly would.
+func TestExecutorStatusSnapshotExposesGuardedFields(t *testing.T) {
+	es := &ExecutorStatus{}
+	start := time.Now()
+	es.setStartTime(start)
+	es.recordProgress()
+	es.addForwardWait(5 * time.Millisecond)
+	es.addBytesForwarded(42)
+	stop := start.Add(time.Second)
+	es.setStopTime(stop)
+
+	snap := es.Snapshot()
+	if !snap.StartTime.Equal(start) {
+		t.Fatalf("Snapshot().StartTime = %v, want %v", snap.StartTime, start)
+	}
+	if !snap.StopTime.Equal(stop) {
+		t.Fatalf("Snapshot().StopTime = %v, want %v", snap.StopTime, stop)
+	}
+	if snap.ForwardWaitTime != 5*time.Millisecond {
+		t.Fatalf("Snapshot().ForwardWaitTime = %v, want %v", snap.ForwardWaitTime, 5*time.Millisecond)
+	}
+	if snap.BytesForwarded != 42 {
+		t.Fatalf("Snapshot().BytesForwarded = %d, want 42", snap.BytesForwarded)
+	}
+	if snap.LastProgressTime.IsZero() {
+		t.Fatalf("Snapshot().LastProgressTime should be set after recordProgress")
+	}
+}
+
+func TestGobEncodedSize(t *testing.T) {
+	n, err := gobEncodedSize("hello")
+	if err != nil {
+		t.Fatalf("gobEncodedSize: %v", err)
+	}
+	if n <= 0 {
+		t.Fatalf("gobEncodedSize = %d, want > 0", n)
+	}
+}
@@ -0,0 +1,292 @@
+package driver
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// CheckpointStore lets a task group spill its output to durable storage, so
+// that a re-run after a hard failure can resume from the last completed
+// shard boundary instead of replaying the whole flow from scratch.
+type CheckpointStore interface {
+	// Writer returns a handle that a task group's WriteChan output can be
+	// spilled to, keyed by task group and attempt.
+	Writer(taskGroupId, attemptId int, shardName string) (io.WriteCloser, error)
+	// Reader opens a previously written checkpoint for a re-run consumer.
+	Reader(taskGroupId, attemptId int, shardName string) (io.ReadCloser, error)
+	// Has reports whether a checkpoint exists for the given shard.
+	Has(taskGroupId, attemptId int, shardName string) bool
+}
+
+// diskCheckpointStore is the default CheckpointStore, spilling each shard to
+// its own file under a base directory.
+type diskCheckpointStore struct {
+	baseDir string
+}
+
+func NewDiskCheckpointStore(baseDir string) CheckpointStore {
+	return &diskCheckpointStore{baseDir: baseDir}
+}
+
+func (s *diskCheckpointStore) path(taskGroupId, attemptId int, shardName string) string {
+	return filepath.Join(s.baseDir, fmt.Sprintf("tg-%d-attempt-%d-%s.chkpt", taskGroupId, attemptId, shardName))
+}
+
+func (s *diskCheckpointStore) Writer(taskGroupId, attemptId int, shardName string) (io.WriteCloser, error) {
+	if err := os.MkdirAll(s.baseDir, 0755); err != nil {
+		return nil, err
+	}
+	return os.Create(s.path(taskGroupId, attemptId, shardName))
+}
+
+func (s *diskCheckpointStore) Reader(taskGroupId, attemptId int, shardName string) (io.ReadCloser, error) {
+	return os.Open(s.path(taskGroupId, attemptId, shardName))
+}
+
+func (s *diskCheckpointStore) Has(taskGroupId, attemptId int, shardName string) bool {
+	_, err := os.Stat(s.path(taskGroupId, attemptId, shardName))
+	return err == nil
+}
+
+// StragglerTimeout is how long a task group's output channel may go without
+// progress before the supervisor considers it a straggler and launches a
+// speculative duplicate.
+const StragglerTimeout = 30 * time.Second
+
+// Supervisor polls a set of running TaskRunners' ExecutorStatus and reacts
+// to stalls or hard failures: it launches speculative duplicates for
+// stragglers, and, on request, drives a replay of a single task group from
+// its last checkpoint rather than restarting the whole flow.
+type Supervisor struct {
+	mu           sync.Mutex
+	runners      map[int]*TaskRunner // taskGroupId -> runner
+	speculated   map[int]bool        // taskGroupId -> already has a speculative duplicate
+	pollInterval time.Duration
+}
+
+func NewSupervisor(pollInterval time.Duration) *Supervisor {
+	return &Supervisor{
+		runners:      make(map[int]*TaskRunner),
+		speculated:   make(map[int]bool),
+		pollInterval: pollInterval,
+	}
+}
+
+func (s *Supervisor) Watch(tr *TaskRunner) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.runners[tr.option.TaskGroupId] = tr
+}
+
+// Poll inspects every watched runner once and returns the task group ids
+// that look stalled, are not cancelled, and have not yet been given a
+// speculative duplicate.
+func (s *Supervisor) Poll() []int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var stalled []int
+	now := time.Now()
+	for taskGroupId, tr := range s.runners {
+		if s.speculated[taskGroupId] || tr.IsCancelled() {
+			continue
+		}
+		status := tr.executorStatus
+		if status.StartTime().IsZero() || !status.StopTime().IsZero() {
+			continue
+		}
+		if now.Sub(status.LastProgressTime()) > StragglerTimeout {
+			stalled = append(stalled, taskGroupId)
+			s.speculated[taskGroupId] = true
+		}
+	}
+	return stalled
+}
+
+// Run starts the supervisor's polling loop. onStraggler is called with the
+// task group ids needing a speculative duplicate; use RunSpeculative to get
+// a real duplicate-launch-and-race implementation instead of writing one.
+func (s *Supervisor) Run(stop <-chan struct{}, onStraggler func(taskGroupIds []int)) {
+	ticker := time.NewTicker(s.pollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			if stalled := s.Poll(); len(stalled) > 0 {
+				onStraggler(stalled)
+			}
+		}
+	}
+}
+
+// RunSpeculative is Run wired to a SpeculativeRacer: every task group Poll
+// flags as stalled gets a speculative duplicate started via launcher, and
+// whichever of the original or the duplicate finishes first wins, with the
+// loser Cancelled. launcher is the one piece that necessarily lives outside
+// this package - it picks another agent and dials it to start the retry -
+// everything else (racing, first-wins, cancellation) is handled here.
+func (s *Supervisor) RunSpeculative(stop <-chan struct{}, launcher DuplicateLauncher) {
+	racer := newSpeculativeRacer(launcher)
+	s.Run(stop, func(taskGroupIds []int) {
+		for _, taskGroupId := range taskGroupIds {
+			s.mu.Lock()
+			original, ok := s.runners[taskGroupId]
+			s.mu.Unlock()
+			if !ok {
+				continue
+			}
+			go racer.race(taskGroupId, original)
+		}
+	})
+}
+
+// CancelTaskGroupRequest is the driver->agent RPC request to cancel and
+// restart a single task group, instead of restarting the whole flow.
+type CancelTaskGroupRequest struct {
+	ContextId   int
+	TaskGroupId int
+	AttemptId   int
+}
+
+type CancelTaskGroupResponse struct {
+	Cancelled bool
+}
+
+// DuplicateLauncher starts a speculative duplicate attempt of a stalled
+// task group - picking another agent and dialing its AgentRPC to start the
+// retry is the launcher's job; the returned TaskRunner is expected to
+// already be running (or about to run) that attempt.
+type DuplicateLauncher func(taskGroupId, attemptId int) *TaskRunner
+
+// racePollInterval is how often a speculativeRacer checks whether the
+// original or duplicate attempt has finished.
+const racePollInterval = 50 * time.Millisecond
+
+// speculativeRacer implements the "first-wins" half of fault tolerance:
+// given a stalled task group, it starts one duplicate attempt and races it
+// against the original, cancelling whichever one loses.
+type speculativeRacer struct {
+	launcher DuplicateLauncher
+
+	mu          sync.Mutex
+	nextAttempt map[int]int
+}
+
+func newSpeculativeRacer(launcher DuplicateLauncher) *speculativeRacer {
+	return &speculativeRacer{
+		launcher:    launcher,
+		nextAttempt: make(map[int]int),
+	}
+}
+
+func (r *speculativeRacer) race(taskGroupId int, original *TaskRunner) {
+	if r.launcher == nil {
+		return
+	}
+
+	r.mu.Lock()
+	r.nextAttempt[taskGroupId]++
+	attemptId := r.nextAttempt[taskGroupId]
+	r.mu.Unlock()
+
+	duplicate := r.launcher(taskGroupId, attemptId)
+	if duplicate == nil {
+		return
+	}
+
+	winner := make(chan *TaskRunner, 2)
+	go watchForCompletion(original, winner)
+	go watchForCompletion(duplicate, winner)
+
+	first := <-winner
+	if first == original {
+		duplicate.Cancel()
+	} else {
+		original.Cancel()
+	}
+}
+
+// watchForCompletion polls tr's ExecutorStatus until it finishes running and
+// reports tr on the done channel - whichever attempt reports first wins the
+// race, so its sibling can be cancelled as the loser.
+func watchForCompletion(tr *TaskRunner, done chan<- *TaskRunner) {
+	for tr.executorStatus.StopTime().IsZero() {
+		if tr.IsCancelled() {
+			return
+		}
+		time.Sleep(racePollInterval)
+	}
+	done <- tr
+}
+
+// AgentRPC is registered on each agent process (via net/rpc) so the driver
+// can cancel and restart a single task group's attempt - e.g. after it
+// loses a speculative race, or to replay it from its last checkpoint -
+// without tearing down the whole flow.
+type AgentRPC struct {
+	mu      sync.Mutex
+	runners map[int]*TaskRunner // taskGroupId -> active attempt on this agent
+	restart func(taskGroupId, attemptId int)
+}
+
+// NewAgentRPC creates an AgentRPC. restart, if non-nil, is called (in its own
+// goroutine) with the request's TaskGroupId and AttemptId every time
+// CancelTaskGroup cancels a registered attempt.
+func NewAgentRPC(restart func(taskGroupId, attemptId int)) *AgentRPC {
+	return &AgentRPC{
+		runners: make(map[int]*TaskRunner),
+		restart: restart,
+	}
+}
+
+// Register tracks tr as this agent's active attempt for its task group, so
+// a later CancelTaskGroup call can find and cancel it.
+func (a *AgentRPC) Register(tr *TaskRunner) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.runners[tr.option.TaskGroupId] = tr
+}
+
+// CancelTaskGroup is the net/rpc method backing CancelTaskGroupRequest. It
+// finds the local attempt and calls Cancel on it - see Cancel's doc comment
+// for exactly what that does and doesn't stop - and then, if a restart func
+// was given to NewAgentRPC, starts req.AttemptId through it.
+func (a *AgentRPC) CancelTaskGroup(req CancelTaskGroupRequest, resp *CancelTaskGroupResponse) error {
+	a.mu.Lock()
+	tr, ok := a.runners[req.TaskGroupId]
+	a.mu.Unlock()
+	if !ok {
+		resp.Cancelled = false
+		return nil
+	}
+
+	tr.Cancel()
+	resp.Cancelled = true
+	if a.restart != nil {
+		go a.restart(req.TaskGroupId, req.AttemptId)
+	}
+	return nil
+}
+
+// ResizePool is the net/rpc method backing ResizePoolRequest: it grows the
+// running task group's ForwarderPool by ExtraWorkers without restarting the
+// attempt.
+func (a *AgentRPC) ResizePool(req ResizePoolRequest, resp *ResizePoolResponse) error {
+	a.mu.Lock()
+	tr, ok := a.runners[req.TaskGroupId]
+	a.mu.Unlock()
+	if !ok {
+		resp.Resized = false
+		return nil
+	}
+
+	tr.resizePool(req.ExtraWorkers)
+	resp.Resized = true
+	return nil
+}
@@ -0,0 +1,134 @@
+package driver
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"io"
+	"io/ioutil"
+	"testing"
+)
+
+// TestNewTaskRunnerThreadsAttemptId checks that TaskOption.AttemptId actually
+// reaches TaskRunner.attemptId, so resumeIndex and checkpointWriter - which
+// key off attemptId - see a duplicate or replay's real attempt number rather
+// than always behaving like attempt 0. Unlike attemptId, external channel
+// names never key off the attempt - see connectExternalInputs.
+func TestNewTaskRunnerThreadsAttemptId(t *testing.T) {
+	tr := NewTaskRunner(&TaskOption{TaskGroupId: 1, AttemptId: 2})
+
+	if tr.attemptId != 2 {
+		t.Fatalf("attemptId = %d, want 2", tr.attemptId)
+	}
+}
+
+// TestCancelClosesCancelCh checks that Cancel closes cancelCh exactly once
+// even if called more than once - e.g. a retried CancelTaskGroup RPC - since
+// closing an already-closed channel panics.
+func TestCancelClosesCancelCh(t *testing.T) {
+	tr := NewTaskRunner(&TaskOption{TaskGroupId: 1})
+
+	tr.Cancel()
+	tr.Cancel()
+
+	select {
+	case <-tr.cancelCh:
+	default:
+		t.Fatalf("cancelCh was not closed")
+	}
+	if !tr.IsCancelled() {
+		t.Fatalf("IsCancelled() = false, want true")
+	}
+}
+
+// fakeLoggerSpy records every Error call, so a test can check that a
+// TaskRunner's Error events reach its configured Logger.
+type fakeLoggerSpy struct {
+	NoopLogger
+	errors []error
+}
+
+func (l *fakeLoggerSpy) Error(f Fields, err error) {
+	l.errors = append(l.errors, err)
+}
+
+// TestCheckpointWriterUsesAttemptId checks that checkpointWriter keys its
+// CheckpointStore call off the TaskRunner's own attemptId rather than always
+// attempt 0.
+func TestCheckpointWriterUsesAttemptId(t *testing.T) {
+	var gotAttemptId = -1
+	store := &recordingCheckpointStore{
+		onWriter: func(taskGroupId, attemptId int, shardName string) {
+			gotAttemptId = attemptId
+		},
+	}
+	tr := NewTaskRunner(&TaskOption{TaskGroupId: 1, AttemptId: 3, CheckpointStore: store})
+
+	if enc := tr.checkpointWriter("shard-0"); enc == nil {
+		t.Fatalf("checkpointWriter returned nil, want a checkpointEncoder")
+	} else {
+		enc.Close()
+	}
+
+	if gotAttemptId != 3 {
+		t.Fatalf("checkpointWriter used attemptId %d, want 3", gotAttemptId)
+	}
+}
+
+type recordingCheckpointStore struct {
+	onWriter func(taskGroupId, attemptId int, shardName string)
+}
+
+func (s *recordingCheckpointStore) Writer(taskGroupId, attemptId int, shardName string) (io.WriteCloser, error) {
+	s.onWriter(taskGroupId, attemptId, shardName)
+	return nopWriteCloser{ioutil.Discard}, nil
+}
+
+func (s *recordingCheckpointStore) Reader(taskGroupId, attemptId int, shardName string) (io.ReadCloser, error) {
+	return nil, errors.New("recordingCheckpointStore.Reader not used by this test")
+}
+
+func (s *recordingCheckpointStore) Has(taskGroupId, attemptId int, shardName string) bool {
+	return false
+}
+
+type nopWriteCloser struct{ io.Writer }
+
+func (nopWriteCloser) Close() error { return nil }
+
+// TestResumeFromCheckpointDistinguishesCorruptionFromEOF checks the
+// predicate resumeFromCheckpoint uses to decide whether a gob decode
+// failure is a clean end-of-stream (nothing to log) or corruption (must go
+// through the Logger): a truncated stream must not be mistaken for io.EOF.
+func TestResumeFromCheckpointDistinguishesCorruptionFromEOF(t *testing.T) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(42); err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	truncated := buf.Bytes()[:buf.Len()-1]
+
+	dec := gob.NewDecoder(bytes.NewReader(truncated))
+	var v int
+	err := dec.Decode(&v)
+	if err == nil {
+		t.Fatalf("Decode of a truncated stream unexpectedly succeeded")
+	}
+	if errors.Is(err, io.EOF) {
+		t.Fatalf("Decode of a truncated stream returned io.EOF, want a distinct corruption error")
+	}
+
+	spy := &fakeLoggerSpy{}
+	tr := NewTaskRunner(&TaskOption{TaskGroupId: 1, Logger: spy})
+	tr.logger().Error(tr.fields("checkpoint_replay", "shard-0"), err)
+	if len(spy.errors) != 1 {
+		t.Fatalf("logger recorded %d errors, want 1", len(spy.errors))
+	}
+
+	cleanDec := gob.NewDecoder(bytes.NewReader(buf.Bytes()))
+	if err := cleanDec.Decode(&v); err != nil {
+		t.Fatalf("Decode of the full stream: %v", err)
+	}
+	if err := cleanDec.Decode(&v); !errors.Is(err, io.EOF) {
+		t.Fatalf("Decode past end of a clean stream = %v, want io.EOF", err)
+	}
+}
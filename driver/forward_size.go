@@ -0,0 +1,26 @@
+package driver
+
+import "encoding/gob"
+
+// byteCounter is an io.Writer that only tallies how many bytes were
+// written to it, so gobEncodedSize doesn't have to buffer the encoded form
+// in memory just to measure it.
+type byteCounter struct {
+	n int64
+}
+
+func (b *byteCounter) Write(p []byte) (int, error) {
+	b.n += int64(len(p))
+	return len(p), nil
+}
+
+// gobEncodedSize returns how many bytes v would occupy gob-encoded, for
+// mirroring a shard's forwarded throughput into ExecutorStatus.BytesForwarded
+// without actually persisting it anywhere.
+func gobEncodedSize(v interface{}) (int64, error) {
+	var counter byteCounter
+	if err := gob.NewEncoder(&counter).Encode(v); err != nil {
+		return 0, err
+	}
+	return counter.n, nil
+}
@@ -0,0 +1,267 @@
+package driver
+
+import (
+	"reflect"
+	"sync"
+	"sync/atomic"
+
+	"github.com/chrislusf/glow/flow"
+)
+
+// DefaultForwarderPoolSize and DefaultForwarderBatchSize are used when a
+// TaskOption doesn't configure a pool size, preserving today's one-item-at-
+// a-time forwarding behavior at a small, bounded worker count instead of
+// one goroutine per shard pair.
+const (
+	DefaultForwarderPoolSize  = 16
+	DefaultForwarderBatchSize = 1
+)
+
+// forwardBatch is the unit of work a ForwarderPool worker executes. run
+// does the actual delivery; it's a closure rather than a (shard, items)
+// pair so the pool's ordering logic can be exercised without a real
+// flow.DatasetShard. queue is the shardQueue this batch belongs to, so the
+// worker that runs it can release the next pending batch for the same key.
+type forwardBatch struct {
+	run   func()
+	queue *shardQueue
+}
+
+// shardQueue single-flights batches submitted under the same key (one shard
+// pair), so the pool's shared workers never run two batches from the same
+// pair concurrently - which would let them be delivered out of order - and
+// so Wait can block until every batch submitted for the key has actually
+// run, not merely dequeued.
+type shardQueue struct {
+	mu      sync.Mutex
+	pending []forwardBatch
+	active  bool
+	wg      sync.WaitGroup
+}
+
+// complete is called by the worker that just ran a batch for this queue. If
+// another batch for the same key is waiting, it's handed to the pool to run
+// next; otherwise the queue goes idle.
+func (q *shardQueue) complete(p *ForwarderPool) {
+	q.mu.Lock()
+	if len(q.pending) > 0 {
+		next := q.pending[0]
+		q.pending = q.pending[1:]
+		q.mu.Unlock()
+		p.enqueue(next)
+		q.wg.Done()
+		return
+	}
+	q.active = false
+	q.mu.Unlock()
+	q.wg.Done()
+}
+
+// PoolStatus is a snapshot of a ForwarderPool's load, as returned by Status.
+// TaskRunner copies it into ExecutorStatus.ForwarderPoolStatus once, when it
+// closes the pool at the end of Run.
+// There is no Dropped counter: Submit never discards a batch, since
+// dropping shard data would silently corrupt the flow's output. QueueDepth
+// counts batches handed to the job queue, including ones still blocked on
+// a full queue; batches waiting on a key's pending list are not counted.
+type PoolStatus struct {
+	ActiveWorkers int32
+	QueueDepth    int32
+}
+
+// ForwarderPool bounds the goroutines and queue depth used to forward
+// values between adjacent shards within a task group. Flows with hundreds
+// of chained tasks previously got one unbounded, always-blocked-in-a-loop
+// goroutine per shard pair; a ForwarderPool instead runs a fixed number of
+// workers draining a bounded queue of batched copy jobs, one shard pair's
+// batches single-flighted through a shardQueue so delivery order is kept
+// even though any worker may pick up any job. The job queue is bounded; a
+// shard pair's pending list, which holds its batches while an earlier one
+// is in flight, is not.
+type ForwarderPool struct {
+	jobs      chan forwardBatch
+	wg        sync.WaitGroup
+	status    PoolStatus
+	batchSize int
+
+	queuesMu sync.Mutex
+	queues   map[interface{}]*shardQueue
+}
+
+// NewForwarderPool starts workerCount workers draining a queue bounded to
+// queueSize batches of up to batchSize items each.
+func NewForwarderPool(workerCount, queueSize, batchSize int) *ForwarderPool {
+	if workerCount <= 0 {
+		workerCount = DefaultForwarderPoolSize
+	}
+	if batchSize <= 0 {
+		batchSize = DefaultForwarderBatchSize
+	}
+	p := &ForwarderPool{
+		jobs:      make(chan forwardBatch, queueSize),
+		batchSize: batchSize,
+		queues:    make(map[interface{}]*shardQueue),
+	}
+	for i := 0; i < workerCount; i++ {
+		p.wg.Add(1)
+		go p.worker()
+	}
+	return p
+}
+
+func (p *ForwarderPool) worker() {
+	defer p.wg.Done()
+	atomic.AddInt32(&p.status.ActiveWorkers, 1)
+	defer atomic.AddInt32(&p.status.ActiveWorkers, -1)
+	for batch := range p.jobs {
+		atomic.AddInt32(&p.status.QueueDepth, -1)
+		if batch.run != nil {
+			batch.run()
+		}
+		batch.queue.complete(p)
+	}
+}
+
+// queueFor returns the shardQueue for key, creating it on first use. key
+// identifies one shard pair - Forward uses the destination shard pointer,
+// so all of a pair's batches share one queue regardless of which worker
+// ends up running them.
+func (p *ForwarderPool) queueFor(key interface{}) *shardQueue {
+	p.queuesMu.Lock()
+	defer p.queuesMu.Unlock()
+	q, ok := p.queues[key]
+	if !ok {
+		q = &shardQueue{}
+		p.queues[key] = q
+	}
+	return q
+}
+
+func (p *ForwarderPool) enqueue(b forwardBatch) {
+	atomic.AddInt32(&p.status.QueueDepth, 1)
+	p.jobs <- b
+}
+
+// Submit enqueues run under key. If no batch for key is in flight, run is
+// handed to the pool's job queue, and Submit blocks while that queue is
+// full. Otherwise run is appended to key's pending list and Submit returns
+// at once: the pending list has no bound, so a key with a batch in flight
+// gets no backpressure. Batches submitted under the same key always run in
+// submission order, one at a time.
+func (p *ForwarderPool) Submit(key interface{}, run func()) {
+	q := p.queueFor(key)
+	q.wg.Add(1)
+	q.mu.Lock()
+	if q.active {
+		q.pending = append(q.pending, forwardBatch{run: run, queue: q})
+		q.mu.Unlock()
+		return
+	}
+	q.active = true
+	q.mu.Unlock()
+	p.enqueue(forwardBatch{run: run, queue: q})
+}
+
+// Wait blocks until every batch submitted under key has actually run, not
+// merely been dequeued. Forward calls this before closing the destination
+// shard's read side, so CloseRead can never race the worker still
+// delivering the last batch.
+func (p *ForwarderPool) Wait(key interface{}) {
+	p.queueFor(key).wg.Wait()
+}
+
+// BatchSize returns how many items Forward should accumulate per hop before
+// submitting them as a single job.
+func (p *ForwarderPool) BatchSize() int {
+	return p.batchSize
+}
+
+// Status returns a snapshot of the pool's current load.
+func (p *ForwarderPool) Status() PoolStatus {
+	return PoolStatus{
+		ActiveWorkers: atomic.LoadInt32(&p.status.ActiveWorkers),
+		QueueDepth:    atomic.LoadInt32(&p.status.QueueDepth),
+	}
+}
+
+// Close stops accepting new batches and waits for queued work to drain.
+func (p *ForwarderPool) Close() {
+	close(p.jobs)
+	p.wg.Wait()
+}
+
+// Resize adds extra workers to the pool. Workers only ever exit when the
+// pool is Closed, so shrinking happens naturally as Close drains the queue
+// rather than by killing workers mid-batch.
+func (p *ForwarderPool) Resize(extraWorkers int) {
+	for i := 0; i < extraWorkers; i++ {
+		p.wg.Add(1)
+		go p.worker()
+	}
+}
+
+// ResizePoolRequest is the driver RPC to reconfigure a running task group's
+// ForwarderPool without restarting it.
+type ResizePoolRequest struct {
+	TaskGroupId  int
+	ExtraWorkers int
+}
+
+type ResizePoolResponse struct {
+	Resized bool
+}
+
+// Forward reads currentShard.WriteChan until it closes, batching up to
+// BatchSize items per hop before submitting them to the pool keyed on
+// nextShard, then waits for every submitted batch to actually be delivered
+// before closing nextShard's read side - submitting and immediately closing
+// would otherwise race the worker goroutine still delivering the last
+// batch. onReceive is called with every successfully received item, letting
+// the caller track forwarding progress/wait time and tap the item (e.g. to
+// checkpoint it) before it's batched.
+//
+// cancel lets a losing speculative attempt stop forwarding promptly instead
+// of running until currentShard.WriteChan closes on its own: once cancel is
+// closed, Forward drops whatever's left in the current batch and closes
+// nextShard's read side right away - see TaskRunner.Cancel. Pass a channel
+// that's never closed to forward until completion as before.
+func (p *ForwarderPool) Forward(currentShard, nextShard *flow.DatasetShard, cancel <-chan struct{}, onReceive func(reflect.Value)) {
+	key := nextShard
+	deliver := func(items []reflect.Value) {
+		p.Submit(key, func() {
+			for _, item := range items {
+				nextShard.SendForRead(item)
+			}
+		})
+	}
+
+	cases := []reflect.SelectCase{
+		{Dir: reflect.SelectRecv, Chan: currentShard.WriteChan},
+		{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(cancel)},
+	}
+
+	batch := make([]reflect.Value, 0, p.batchSize)
+	for {
+		chosen, t, ok := reflect.Select(cases)
+		if chosen == 1 {
+			nextShard.CloseRead()
+			return
+		}
+		if !ok {
+			if len(batch) > 0 {
+				deliver(batch)
+			}
+			p.Wait(key)
+			nextShard.CloseRead()
+			return
+		}
+		if onReceive != nil {
+			onReceive(t)
+		}
+		batch = append(batch, t)
+		if len(batch) >= p.batchSize {
+			deliver(batch)
+			batch = make([]reflect.Value, 0, p.batchSize)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package driver
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// waitForActiveWorkers polls until the pool reports at least want active
+// workers, or fails the test after a short timeout. Worker goroutines start
+// up asynchronously, so callers can't assert on Status() immediately.
+func waitForActiveWorkers(t *testing.T, p *ForwarderPool, want int32) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		if p.Status().ActiveWorkers >= want {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("ActiveWorkers never reached %d, last status = %+v", want, p.Status())
+}
+
+func TestNewForwarderPoolDefaults(t *testing.T) {
+	p := NewForwarderPool(0, 0, 0)
+	defer p.Close()
+
+	waitForActiveWorkers(t, p, DefaultForwarderPoolSize)
+	if got := p.BatchSize(); got != DefaultForwarderBatchSize {
+		t.Fatalf("BatchSize() = %d, want %d", got, DefaultForwarderBatchSize)
+	}
+}
+
+func TestForwarderPoolSubmitDrainsQueue(t *testing.T) {
+	p := NewForwarderPool(2, 4, 1)
+	defer p.Close()
+	waitForActiveWorkers(t, p, 2)
+
+	// No-op runs exercise Submit/Status/worker draining without needing a
+	// real flow.DatasetShard to forward into. Distinct keys let all 4 run
+	// concurrently instead of single-flighting through one shardQueue.
+	for i := 0; i < 4; i++ {
+		p.Submit(i, nil)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		if p.Status().QueueDepth == 0 {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("QueueDepth never drained to 0, last status = %+v", p.Status())
+}
+
+func TestForwarderPoolResize(t *testing.T) {
+	p := NewForwarderPool(1, 4, 1)
+	defer p.Close()
+	waitForActiveWorkers(t, p, 1)
+
+	p.Resize(2)
+	waitForActiveWorkers(t, p, 3)
+}
+
+// TestForwarderPoolSameKeyRunsInOrder guards against the pool's shared
+// workers delivering two batches from the same shard pair out of order:
+// with several workers racing, only single-flighting batches submitted
+// under the same key keeps appends to seen in submission order.
+func TestForwarderPoolSameKeyRunsInOrder(t *testing.T) {
+	p := NewForwarderPool(8, 32, 1)
+	defer p.Close()
+	waitForActiveWorkers(t, p, 8)
+
+	const key = "shard-pair"
+	var mu sync.Mutex
+	var seen []int
+	for i := 0; i < 20; i++ {
+		i := i
+		p.Submit(key, func() {
+			mu.Lock()
+			seen = append(seen, i)
+			mu.Unlock()
+		})
+	}
+	p.Wait(key)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(seen) != 20 {
+		t.Fatalf("got %d runs, want 20: %v", len(seen), seen)
+	}
+	for i, v := range seen {
+		if v != i {
+			t.Fatalf("seen out of order: %v", seen)
+		}
+	}
+}
+
+// TestForwarderPoolWaitBlocksUntilDelivered makes sure Wait doesn't return
+// (and so a caller like Forward doesn't CloseRead) until the batch has
+// actually run, not merely been dequeued.
+func TestForwarderPoolWaitBlocksUntilDelivered(t *testing.T) {
+	p := NewForwarderPool(1, 4, 1)
+	defer p.Close()
+	waitForActiveWorkers(t, p, 1)
+
+	var delivered int32
+	p.Submit("key", func() {
+		time.Sleep(20 * time.Millisecond)
+		atomic.StoreInt32(&delivered, 1)
+	})
+	p.Wait("key")
+
+	if atomic.LoadInt32(&delivered) != 1 {
+		t.Fatalf("Wait returned before the batch ran")
+	}
+}
@@ -0,0 +1,89 @@
+package driver
+
+import (
+	"github.com/Sirupsen/logrus"
+)
+
+// Fields carries the structured context a Logger call is tagged with, so
+// operators can filter and correlate events by task group rather than
+// grepping plain-text println output.
+type Fields struct {
+	TaskGroupId int
+	ContextId   int
+	ShardName   string
+	Phase       string
+}
+
+// Logger is the pluggable sink for TaskRunner lifecycle events. The
+// zero-value TaskOption gets a NoopLogger so existing deployments behave
+// exactly as before; operators wire in a LogrusLogger (or their own
+// implementation) to get connect/start/stop/error diagnostics into their
+// observability stack.
+type Logger interface {
+	Connect(f Fields, msg string)
+	Start(f Fields, msg string)
+	Stop(f Fields, msg string)
+	Error(f Fields, err error)
+}
+
+// NoopLogger discards every event, matching the driver's historical
+// behavior of only emitting commented-out debug prints.
+type NoopLogger struct{}
+
+func (NoopLogger) Connect(Fields, string) {}
+func (NoopLogger) Start(Fields, string)   {}
+func (NoopLogger) Stop(Fields, string)    {}
+func (NoopLogger) Error(Fields, error)    {}
+
+// LogrusLogger is the default structured Logger, emitting one logrus entry
+// per event with the task group/context/shard/phase as fields.
+type LogrusLogger struct {
+	log *logrus.Logger
+}
+
+func NewLogrusLogger() *LogrusLogger {
+	return &LogrusLogger{log: logrus.StandardLogger()}
+}
+
+func (l *LogrusLogger) entry(f Fields) *logrus.Entry {
+	return l.log.WithFields(logrus.Fields{
+		"task_group_id": f.TaskGroupId,
+		"context_id":    f.ContextId,
+		"shard_name":    f.ShardName,
+		"phase":         f.Phase,
+	})
+}
+
+func (l *LogrusLogger) Connect(f Fields, msg string) {
+	l.entry(f).Info(msg)
+}
+
+func (l *LogrusLogger) Start(f Fields, msg string) {
+	l.entry(f).Info(msg)
+}
+
+func (l *LogrusLogger) Stop(f Fields, msg string) {
+	l.entry(f).Info(msg)
+}
+
+func (l *LogrusLogger) Error(f Fields, err error) {
+	l.entry(f).WithError(err).Error("task runner error")
+}
+
+// logger returns the TaskOption's configured Logger, defaulting to a
+// NoopLogger so flows that don't opt in keep today's silent behavior.
+func (tr *TaskRunner) logger() Logger {
+	if tr.option.Logger != nil {
+		return tr.option.Logger
+	}
+	return NoopLogger{}
+}
+
+func (tr *TaskRunner) fields(phase, shardName string) Fields {
+	return Fields{
+		TaskGroupId: tr.option.TaskGroupId,
+		ContextId:   tr.option.ContextId,
+		ShardName:   shardName,
+		Phase:       phase,
+	}
+}
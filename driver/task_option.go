@@ -0,0 +1,41 @@
+package driver
+
+import "github.com/chrislusf/glow/netchan"
+
+// TaskOption configures how a TaskRunner executes one task group: which
+// context/task group it belongs to, how its external channels are named,
+// sized and carried, and - as an attempt is retried - where to read and
+// write its checkpoint.
+type TaskOption struct {
+	ContextId          int
+	TaskGroupId        int
+	AttemptId          int
+	ExecutableFileHash string
+	Inputs             string
+	ChannelBufferSize  int
+
+	// CheckpointStore, if set, lets a retried attempt resume a shard
+	// boundary from the previous attempt's checkpoint instead of
+	// recomputing it. Left nil, every attempt runs from scratch.
+	CheckpointStore CheckpointStore
+
+	// Transport selects the netchan.Transport external channels are
+	// carried over. Left nil, TaskRunner falls back to
+	// netchan.DefaultTransport (plain TCP).
+	Transport netchan.Transport
+
+	// Logger receives this task group's lifecycle events. Left nil,
+	// TaskRunner uses a NoopLogger and stays silent, matching historical
+	// behavior.
+	Logger Logger
+
+	// ForwarderPoolSize is the number of workers the TaskRunner's
+	// ForwarderPool uses to move values between adjacent tasks' shards.
+	// Left zero, NewForwarderPool's own defaulting applies.
+	ForwarderPoolSize int
+
+	// ForwarderBatchSize is how many values the ForwarderPool reads off a
+	// shard before handing them to a worker as one batch. Left zero,
+	// NewForwarderPool's own defaulting applies.
+	ForwarderBatchSize int
+}
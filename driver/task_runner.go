@@ -2,10 +2,10 @@ package driver
 
 import (
 	"fmt"
-	"log"
 	"reflect"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/chrislusf/glow/driver/plan"
@@ -19,20 +19,175 @@ type TaskRunner struct {
 	Tasks          []*flow.Task
 	FlowContext    *flow.FlowContext
 	executorStatus *ExecutorStatus
+	attemptId      int
+	cancelled      int32
+	cancelCh       chan struct{}
+
+	// poolMu guards forwarderPool's lazy creation, resizing and closing -
+	// ResizePool's RPC handler and Run's own connect/close path reach it
+	// from different goroutines, and Resize's wg.Add must never run
+	// concurrently with Close's wg.Wait.
+	poolMu        sync.Mutex
+	forwarderPool *ForwarderPool
+
+	// resumeAt is the highest adjacent-shard-pair index being replayed
+	// from checkpoint this run, or -1 if none is. Tasks 0..resumeAt are
+	// not executed - see resumeIndex and resumeFromCheckpoint.
+	resumeAt int
 }
 
 type ExecutorStatus struct {
 	InputChannelStatuses []*util.ChannelStatus
 	OutputChannelStatus  *util.ChannelStatus
 	ReadyTime            time.Time
+	TransportMetrics     []netchan.TransportMetrics
+	ForwarderPoolStatus  PoolStatus
+
+	// progressMu guards every field below: startTime/stopTime are written
+	// from Run's goroutine and read concurrently by Supervisor.Poll and
+	// watchForCompletion; lastProgressTime/forwardWaitTime/bytesForwarded
+	// are written from every inter-task forwarder goroutine and from
+	// external channel activity, and likewise read concurrently by Poll.
+	progressMu       sync.Mutex
+	startTime        time.Time
+	stopTime         time.Time
+	lastProgressTime time.Time
+	forwardWaitTime  time.Duration
+	bytesForwarded   int64
+}
+
+// recordProgress marks that the task group made forward progress just now.
+// Called from forwarder goroutines, from checkpoint replay, once as each
+// external channel is connected, and every heartbeatInterval while a task
+// runs, so any task group - even a single-task one with no inter-task
+// forwarding - keeps the Supervisor from misclassifying it as a straggler.
+func (es *ExecutorStatus) recordProgress() {
+	es.progressMu.Lock()
+	es.lastProgressTime = time.Now()
+	es.progressMu.Unlock()
+}
+
+func (es *ExecutorStatus) addForwardWait(d time.Duration) {
+	es.progressMu.Lock()
+	es.forwardWaitTime += d
+	es.progressMu.Unlock()
+}
+
+func (es *ExecutorStatus) addBytesForwarded(n int64) {
+	es.progressMu.Lock()
+	es.bytesForwarded += n
+	es.progressMu.Unlock()
+}
+
+func (es *ExecutorStatus) setStartTime(t time.Time) {
+	es.progressMu.Lock()
+	es.startTime = t
+	es.progressMu.Unlock()
+}
+
+func (es *ExecutorStatus) setStopTime(t time.Time) {
+	es.progressMu.Lock()
+	es.stopTime = t
+	es.progressMu.Unlock()
+}
+
+// StartTime returns when this task group's attempt started running, or the
+// zero Time if it hasn't yet.
+func (es *ExecutorStatus) StartTime() time.Time {
+	es.progressMu.Lock()
+	defer es.progressMu.Unlock()
+	return es.startTime
+}
+
+// StopTime returns when this task group's attempt finished running, or the
+// zero Time while it's still running.
+func (es *ExecutorStatus) StopTime() time.Time {
+	es.progressMu.Lock()
+	defer es.progressMu.Unlock()
+	return es.stopTime
+}
+
+// LastProgressTime returns the last time this task group's status was
+// updated with forward progress.
+func (es *ExecutorStatus) LastProgressTime() time.Time {
+	es.progressMu.Lock()
+	defer es.progressMu.Unlock()
+	return es.lastProgressTime
+}
+
+// ForwardWaitTime returns the cumulative time every inter-task forwarder
+// goroutine in this task group spent between one received value and the
+// next (for the first, since forwarding started): time blocked waiting to
+// receive, plus the time spent measuring, checkpointing and submitting the
+// previous value.
+func (es *ExecutorStatus) ForwardWaitTime() time.Duration {
+	es.progressMu.Lock()
+	defer es.progressMu.Unlock()
+	return es.forwardWaitTime
+}
+
+// BytesForwarded returns the cumulative gob-encoded size of every value
+// every inter-task forwarder goroutine in this task group has forwarded.
+func (es *ExecutorStatus) BytesForwarded() int64 {
+	es.progressMu.Lock()
+	defer es.progressMu.Unlock()
+	return es.bytesForwarded
+}
+
+// ExecutorStatusSnapshot is a plain-struct, unguarded copy of an
+// ExecutorStatus at one instant - unlike ExecutorStatus itself, every field
+// here is exported and safe for reflection-based marshaling (e.g. JSON for
+// a status page or dashboard RPC), since progressMu's fields aren't struct
+// fields on ExecutorStatus and wouldn't otherwise be visible to a marshaler.
+type ExecutorStatusSnapshot struct {
+	InputChannelStatuses []*util.ChannelStatus
+	OutputChannelStatus  *util.ChannelStatus
+	ReadyTime            time.Time
+	TransportMetrics     []netchan.TransportMetrics
+	ForwarderPoolStatus  PoolStatus
 	StartTime            time.Time
 	StopTime             time.Time
+	LastProgressTime     time.Time
+	ForwardWaitTime      time.Duration
+	BytesForwarded       int64
+}
+
+// Snapshot returns a point-in-time copy of es suitable for serializing or
+// handing to a status page, taking progressMu only long enough to copy out
+// the guarded fields.
+func (es *ExecutorStatus) Snapshot() ExecutorStatusSnapshot {
+	es.progressMu.Lock()
+	defer es.progressMu.Unlock()
+	return ExecutorStatusSnapshot{
+		InputChannelStatuses: es.InputChannelStatuses,
+		OutputChannelStatus:  es.OutputChannelStatus,
+		ReadyTime:            es.ReadyTime,
+		TransportMetrics:     es.TransportMetrics,
+		ForwarderPoolStatus:  es.ForwarderPoolStatus,
+		StartTime:            es.startTime,
+		StopTime:             es.stopTime,
+		LastProgressTime:     es.lastProgressTime,
+		ForwardWaitTime:      es.forwardWaitTime,
+		BytesForwarded:       es.bytesForwarded,
+	}
+}
+
+// transport returns the TaskOption's selected transport, falling back to
+// plain TCP for existing flows that don't configure one.
+func (tr *TaskRunner) transport() netchan.Transport {
+	if tr.option.Transport != nil {
+		return tr.option.Transport
+	}
+	return netchan.DefaultTransport
 }
 
 func NewTaskRunner(option *TaskOption) *TaskRunner {
 	return &TaskRunner{
 		option:         option,
+		attemptId:      option.AttemptId,
 		executorStatus: &ExecutorStatus{},
+		resumeAt:       -1,
+		cancelCh:       make(chan struct{}),
 	}
 }
 
@@ -40,6 +195,50 @@ func (tr *TaskRunner) IsTaskMode() bool {
 	return tr.option.TaskGroupId >= 0 && tr.option.ContextId >= 0
 }
 
+// heartbeatInterval governs how often a running task's own execution marks
+// forward progress, so a single-task group - which never runs the
+// inter-task forwarder goroutine - doesn't sit at its StartTime forever and
+// get misclassified as a straggler by Supervisor.Poll.
+const heartbeatInterval = 5 * time.Second
+
+func (tr *TaskRunner) heartbeatWhileRunning(done <-chan struct{}) {
+	ticker := time.NewTicker(heartbeatInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			tr.executorStatus.recordProgress()
+		}
+	}
+}
+
+// Cancel marks this attempt as cancelled, e.g. because a speculative
+// duplicate finished first, or via AgentRPC.CancelTaskGroup. Beyond stopping
+// the attempt from being considered for future straggler detection, closing
+// cancelCh unblocks every inter-task forwarder goroutine parked in
+// ForwarderPool.Forward, so a cancelled attempt stops handing new values to
+// downstream shards within one in-flight batch instead of forwarding to
+// completion.
+//
+// It cannot stop a task already inside task.RunTask(): that call has no
+// cancellation hook, so a cancelled attempt's currently-executing task keeps
+// running to completion, and connectExternalOutputs' channel may still
+// receive some of its output after the cancellation. Shrinking that window
+// further would need a context.Context threaded into flow.Task itself,
+// which this package doesn't have a hook for - a known, accepted gap rather
+// than something Cancel fixes.
+func (tr *TaskRunner) Cancel() {
+	if atomic.CompareAndSwapInt32(&tr.cancelled, 0, 1) {
+		close(tr.cancelCh)
+	}
+}
+
+func (tr *TaskRunner) IsCancelled() bool {
+	return atomic.LoadInt32(&tr.cancelled) == 1
+}
+
 // if this should not run, return false
 func (tr *TaskRunner) Run(fc *flow.FlowContext) {
 	if fc.Id != tr.option.ContextId {
@@ -49,35 +248,48 @@ func (tr *TaskRunner) Run(fc *flow.FlowContext) {
 
 	tr.Tasks = plan.GroupTasks(fc)[tr.option.TaskGroupId].Tasks
 	tr.FlowContext = fc
+	tr.resumeAt = tr.resumeIndex()
 
-	tr.executorStatus.StartTime = time.Now()
+	tr.executorStatus.setStartTime(time.Now())
+	tr.executorStatus.recordProgress()
 
-	// println("taskGroup", tr.Tasks[0].Name(), "starts")
+	tr.logger().Start(tr.fields("task_group", ""), fmt.Sprintf("taskGroup %s starts", tr.Tasks[0].Name()))
 	// 4. setup task input and output channels
 	var wg sync.WaitGroup
 	tr.connectInputsAndOutputs(&wg)
 	// 6. starts to run the task locally
-	for _, task := range tr.Tasks {
-		// println("run task", task.Name())
+	for i, task := range tr.Tasks {
+		if i <= tr.resumeAt {
+			// this task's output is already fully checkpointed and being
+			// replayed by resumeFromCheckpoint - nothing would drain its
+			// output shard if it ran again.
+			tr.logger().Start(tr.fields("task", task.Name()), "skip task: replaying from checkpoint")
+			continue
+		}
+		tr.logger().Start(tr.fields("task", task.Name()), "run task")
 		wg.Add(1)
 		go func(task *flow.Task) {
 			defer wg.Done()
+			done := make(chan struct{})
+			go tr.heartbeatWhileRunning(done)
 			task.RunTask()
+			close(done)
 		}(task)
 	}
 	// 7. need to close connected output channels
 	wg.Wait()
-	// println("taskGroup", tr.Tasks[0].Name(), "finishes")
-	tr.executorStatus.StopTime = time.Now()
+	tr.closePool()
+	tr.logger().Stop(tr.fields("task_group", ""), fmt.Sprintf("taskGroup %s finishes", tr.Tasks[0].Name()))
+	tr.executorStatus.setStopTime(time.Now())
 }
 
 func (tr *TaskRunner) connectInputsAndOutputs(wg *sync.WaitGroup) {
 	name2Location := make(map[string]string)
 	if tr.option.Inputs != "" {
 		for _, nameLocation := range strings.Split(tr.option.Inputs, ",") {
-			// println("input:", nameLocation)
 			nl := strings.Split(nameLocation, "@")
 			name2Location[nl[0]] = nl[1]
+			tr.logger().Connect(tr.fields("connect", nl[0]), "input: "+nameLocation)
 		}
 	}
 	tr.connectExternalInputChannels(wg)
@@ -86,74 +298,190 @@ func (tr *TaskRunner) connectInputsAndOutputs(wg *sync.WaitGroup) {
 	tr.connectExternalOutputs(wg)
 }
 
+// pool lazily creates the TaskRunner's ForwarderPool, sized from TaskOption
+// so it can be reconfigured per flow without changing call sites.
+func (tr *TaskRunner) pool() *ForwarderPool {
+	tr.poolMu.Lock()
+	defer tr.poolMu.Unlock()
+	return tr.poolLocked()
+}
+
+// poolLocked is pool's lazy-init body, factored out so resizePool and
+// closePool can get-or-create and act on the same ForwarderPool atomically
+// under poolMu instead of racing a second lock acquisition in between.
+func (tr *TaskRunner) poolLocked() *ForwarderPool {
+	if tr.forwarderPool == nil {
+		workerCount := tr.option.ForwarderPoolSize
+		queueSize := tr.option.ForwarderPoolSize * 2
+		if workerCount <= 0 {
+			// NewForwarderPool would default workerCount on its own, but
+			// queueSize is computed here first - default it the same way,
+			// or a flow that doesn't set ForwarderPoolSize gets an
+			// unbuffered queue instead of a bounded one.
+			workerCount = DefaultForwarderPoolSize
+			queueSize = DefaultForwarderPoolSize * 2
+		}
+		tr.forwarderPool = NewForwarderPool(workerCount, queueSize, tr.option.ForwarderBatchSize)
+	}
+	return tr.forwarderPool
+}
+
+// resizePool grows the TaskRunner's ForwarderPool by extraWorkers, e.g. from
+// AgentRPC.ResizePool. It shares poolMu with closePool so a resize can never
+// land its wg.Add concurrently with Run's wg.Wait on Close.
+func (tr *TaskRunner) resizePool(extraWorkers int) {
+	tr.poolMu.Lock()
+	defer tr.poolMu.Unlock()
+	tr.poolLocked().Resize(extraWorkers)
+}
+
+// closePool reports the pool's final status into ExecutorStatus and closes
+// it, if one was ever created. Held under poolMu for the same reason as
+// resizePool - Close's wg.Wait must not overlap a concurrent Resize's
+// wg.Add.
+func (tr *TaskRunner) closePool() {
+	tr.poolMu.Lock()
+	defer tr.poolMu.Unlock()
+	if tr.forwarderPool == nil {
+		return
+	}
+	tr.executorStatus.ForwarderPoolStatus = tr.forwarderPool.Status()
+	tr.forwarderPool.Close()
+}
+
 func (tr *TaskRunner) connectInternalInputsAndOutputs(wg *sync.WaitGroup) {
+	pool := tr.pool()
 	for i, _ := range tr.Tasks {
 		if i == len(tr.Tasks)-1 {
 			continue
 		}
 		currentShard, nextShard := tr.Tasks[i].Outputs[0], tr.Tasks[i+1].Inputs[0]
 
+		if i < tr.resumeAt {
+			// this pair sits entirely before the boundary being replayed -
+			// both the task that would produce currentShard and the task
+			// that would consume nextShard are skipped, so there is
+			// nothing to forward here.
+			continue
+		}
+		if i == tr.resumeAt {
+			tr.resumeFromCheckpoint(wg, currentShard, nextShard)
+			continue
+		}
+
 		currentShard.SetupReadingChans()
 
+		checkpoint := tr.checkpointWriter(currentShard.Name())
+
 		wg.Add(1)
 		go func(currentShard, nextShard *flow.DatasetShard, i int) {
 			defer wg.Done()
-			for {
-				if t, ok := currentShard.WriteChan.Recv(); ok {
-					nextShard.SendForRead(t)
+			if checkpoint != nil {
+				defer checkpoint.Close()
+			}
+			waitStart := time.Now()
+			pool.Forward(currentShard, nextShard, tr.cancelCh, func(t reflect.Value) {
+				tr.executorStatus.addForwardWait(time.Since(waitStart))
+				tr.executorStatus.recordProgress()
+				waitStart = time.Now()
+				if n, err := gobEncodedSize(t.Interface()); err != nil {
+					tr.logger().Error(tr.fields("forward_size", currentShard.Name()), err)
 				} else {
-					nextShard.CloseRead()
-					break
+					tr.executorStatus.addBytesForwarded(n)
 				}
-			}
+				if checkpoint != nil {
+					if err := checkpoint.Encode(t.Interface()); err != nil {
+						tr.logger().Error(tr.fields("checkpoint_write", currentShard.Name()), err)
+					}
+				}
+			})
 		}(currentShard, nextShard, i)
 	}
 }
 
 func (tr *TaskRunner) connectExternalInputs(wg *sync.WaitGroup, name2Location map[string]string) {
+	if tr.resumeAt >= 0 {
+		// the first task is being skipped in favor of a checkpoint replay
+		// further down the pipeline - it never runs, so it has no inputs
+		// to connect.
+		return
+	}
 	firstTask := tr.Tasks[0]
 	for i, shard := range firstTask.Inputs {
 		d := shard.Parent
+		// readChanName is deliberately attempt-agnostic: a speculative
+		// duplicate's output must land on the exact name this (and every
+		// other) reader uses, or a stalled original's duplicate can finish
+		// and still leave downstream waiting forever on a channel nobody
+		// ever writes - see Cancel's doc comment for how far cancelling
+		// the losing attempt stops it from also writing to this name.
 		readChanName := tr.option.ExecutableFileHash + "-" + shard.Name()
-		// println("taskGroup", tr.option.TaskGroupId, "firstTask", firstTask.Name(), "trying to read from:", readChanName, len(firstTask.InputChans))
-		rawChan, err := netchan.GetDirectReadChannel(readChanName, name2Location[readChanName], tr.FlowContext.ChannelBufferSize)
+		f := tr.fields("connect_external_input", readChanName)
+		tr.logger().Connect(f, fmt.Sprintf("firstTask %s trying to read from: %s", firstTask.Name(), readChanName))
+		rawChan, conn, err := netchan.GetDirectReadChannel(readChanName, name2Location[readChanName], tr.FlowContext.ChannelBufferSize, tr.transport())
 		if err != nil {
-			log.Panic(err)
+			tr.logger().Error(f, err)
+			panic(err)
 		}
 		inChanStatus := netchan.ConnectRawReadChannelToTyped(rawChan, firstTask.InputChans[i], d.Type, wg)
 		tr.executorStatus.InputChannelStatuses = append(tr.executorStatus.InputChannelStatuses, inChanStatus)
+		tr.executorStatus.TransportMetrics = append(tr.executorStatus.TransportMetrics, conn.Metrics())
+		tr.executorStatus.recordProgress()
 	}
 }
 
 func (tr *TaskRunner) connectExternalInputChannels(wg *sync.WaitGroup) {
+	if tr.resumeAt >= 0 {
+		// same as connectExternalInputs: the first task never runs.
+		return
+	}
 	// this is only for Channel dataset
 	firstTask := tr.Tasks[0]
 	if firstTask.Inputs != nil {
 		return
 	}
 	ds := firstTask.Outputs[0].Parent
+	var typedInputChans []chan reflect.Value
 	for i, _ := range ds.ExternalInputChans {
 		inputChanName := fmt.Sprintf("%s-ct-%d-input-%d-p-%d", tr.option.ExecutableFileHash, tr.option.ContextId, ds.Id, i)
-		rawChan, err := netchan.GetLocalReadChannel(inputChanName, tr.FlowContext.ChannelBufferSize)
+		f := tr.fields("connect_external_input_channel", inputChanName)
+		rawChan, conn, err := netchan.GetLocalReadChannel(inputChanName, tr.FlowContext.ChannelBufferSize, tr.transport())
 		if err != nil {
-			log.Panic(err)
+			tr.logger().Error(f, err)
+			panic(err)
 		}
 		typedInputChan := make(chan reflect.Value)
 		inChanStatus := netchan.ConnectRawReadChannelToTyped(rawChan, typedInputChan, ds.Type, wg)
 		tr.executorStatus.InputChannelStatuses = append(tr.executorStatus.InputChannelStatuses, inChanStatus)
-		firstTask.InputChans = append(firstTask.InputChans, typedInputChan)
+		tr.executorStatus.TransportMetrics = append(tr.executorStatus.TransportMetrics, conn.Metrics())
+		tr.executorStatus.recordProgress()
+		typedInputChans = append(typedInputChans, typedInputChan)
+	}
+
+	if len(typedInputChans) > 1 && ds.MergeMode != flow.NoMerge {
+		firstTask.InputChans = append(firstTask.InputChans, mergeExternalInputChans(ds.MergeMode, ds.KeyFn, ds.WindowSize, typedInputChans))
+		return
 	}
+	firstTask.InputChans = append(firstTask.InputChans, typedInputChans...)
 }
 
 func (tr *TaskRunner) connectExternalOutputs(wg *sync.WaitGroup) {
 	lastTask := tr.Tasks[len(tr.Tasks)-1]
 	for _, shard := range lastTask.Outputs {
+		// writeChanName matches readChanName in connectExternalInputs: it
+		// must stay canonical (attempt-agnostic) so a speculative duplicate
+		// writes to the same name downstream is already reading from,
+		// instead of a name nobody reads.
 		writeChanName := tr.option.ExecutableFileHash + "-" + shard.Name()
-		// println("taskGroup", tr.option.TaskGroupId, "step", lastTask.Step.Id, "lastTask", lastTask.Id, "writing to:", writeChanName)
-		rawChan, err := netchan.GetLocalSendChannel(writeChanName, wg)
+		f := tr.fields("connect_external_output", writeChanName)
+		tr.logger().Connect(f, fmt.Sprintf("lastTask %s writing to: %s", lastTask.Name(), writeChanName))
+		rawChan, conn, err := netchan.GetLocalSendChannel(writeChanName, tr.transport(), wg)
 		if err != nil {
-			log.Panic(err)
+			tr.logger().Error(f, err)
+			panic(err)
 		}
 		tr.executorStatus.OutputChannelStatus = netchan.ConnectTypedWriteChannelToRaw(shard.WriteChan, rawChan, wg)
+		tr.executorStatus.TransportMetrics = append(tr.executorStatus.TransportMetrics, conn.Metrics())
+		tr.executorStatus.recordProgress()
 	}
 }
@@ -0,0 +1,40 @@
+package driver
+
+import (
+	"sync"
+	"testing"
+)
+
+// TestTaskRunnerPoolDefaultsQueueSize checks that leaving ForwarderPoolSize
+// at its zero default still gets a bounded queue sized the same way
+// NewForwarderPool defaults the worker count, rather than falling back to
+// an unbuffered channel with no slack.
+func TestTaskRunnerPoolDefaultsQueueSize(t *testing.T) {
+	tr := NewTaskRunner(&TaskOption{TaskGroupId: 1})
+	defer tr.closePool()
+
+	p := tr.pool()
+	if got, want := cap(p.jobs), DefaultForwarderPoolSize*2; got != want {
+		t.Fatalf("default queue capacity = %d, want %d", got, want)
+	}
+}
+
+// TestTaskRunnerResizePoolRaceWithClose exercises resizePool and closePool
+// concurrently - run with -race, this is what would have caught Resize's
+// wg.Add racing Close's wg.Wait on the same ForwarderPool.
+func TestTaskRunnerResizePoolRaceWithClose(t *testing.T) {
+	tr := NewTaskRunner(&TaskOption{TaskGroupId: 1, ForwarderPoolSize: 1})
+	tr.pool() // force creation before the race starts
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 50; i++ {
+			tr.resizePool(1)
+		}
+	}()
+
+	tr.closePool()
+	wg.Wait()
+}
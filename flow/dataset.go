@@ -0,0 +1,36 @@
+package flow
+
+import (
+	"reflect"
+	"time"
+)
+
+// Dataset is the flow graph node a Task's shards read from and write to: it
+// carries the dataset's element type, an optional set of external input
+// channels for Channel datasets, and how those external inputs should be
+// merged into the first task's input stream.
+type Dataset struct {
+	Id   int
+	Type reflect.Type
+
+	// ExternalInputChans holds the external channels wired in via a
+	// Channel dataset. TaskRunner reads a local netchan per entry here,
+	// then, per MergeMode, either keeps each as its own InputChans slot or
+	// fuses all of them into a single input stream.
+	ExternalInputChans []reflect.Value
+
+	// MergeMode controls how ExternalInputChans are combined when there is
+	// more than one of them. It defaults to NoMerge, matching the
+	// pre-existing behavior of reading each input channel independently;
+	// set it to Interleave, RoundRobin, ZipByKey, or TimeWindowMerge to
+	// fuse them into a single input stream instead.
+	MergeMode ChannelMergeMode
+	// KeyFn is required when MergeMode is ZipByKey; it extracts the join
+	// key used to line up values across ExternalInputChans.
+	KeyFn KeyFn
+
+	// WindowSize is the batching interval used when MergeMode is
+	// TimeWindowMerge. Left zero, TimeWindowMerge falls back to
+	// driver.DefaultTimeWindowSize.
+	WindowSize time.Duration
+}
@@ -0,0 +1,30 @@
+package flow
+
+// ChannelMergeMode controls how a Dataset with more than one external input
+// channel (see Dataset.ExternalInputChans) is fused into the single input
+// stream its first task reads from.
+type ChannelMergeMode int
+
+const (
+	// NoMerge keeps each external input channel as its own InputChans
+	// slot, read independently. This is the zero value, matching the
+	// pre-existing behavior for any Dataset that doesn't opt into a merge
+	// mode.
+	NoMerge ChannelMergeMode = iota
+	// Interleave forwards values from every input channel as soon as they
+	// arrive, in arrival order, fusing them into a single input stream.
+	Interleave
+	// RoundRobin takes one value from each input channel in turn.
+	RoundRobin
+	// ZipByKey buffers one element per input keyed by KeyFn and emits a
+	// combined tuple once every input has produced a value for that key.
+	ZipByKey
+	// TimeWindowMerge tags each value with its arrival time and emits
+	// sorted batches every WindowSize.
+	TimeWindowMerge
+)
+
+// KeyFn extracts a join key from a value read off one of a Dataset's
+// external input channels, used by ZipByKey to line values up across
+// inputs.
+type KeyFn func(value interface{}) interface{}
@@ -0,0 +1,90 @@
+package netchan
+
+import (
+	"sync"
+)
+
+// RawChan is the byte-level channel ConnectRawReadChannelToTyped and
+// ConnectTypedWriteChannelToRaw read from and write to - each element is
+// one message's already-framed payload, decoded/encoded to/from the
+// channel's real element type at the typed layer above this one.
+type RawChan chan []byte
+
+// localConn is the Conn used for same-process named channels (the "Local"
+// variants below): there's no real network hop, so callers move bytes
+// through the registry's RawChan directly and Send/Receive/Close are no-ops,
+// but it still satisfies Conn so callers can treat local and direct
+// channels uniformly and still get a Metrics() value.
+type localConn struct {
+	name string
+}
+
+func (c *localConn) Send(b []byte) error      { return nil }
+func (c *localConn) Receive() ([]byte, error) { return nil, nil }
+func (c *localConn) Close() error             { return nil }
+func (c *localConn) Metrics() TransportMetrics {
+	return TransportMetrics{Transport: "local"}
+}
+
+var (
+	localChansMu sync.Mutex
+	localChans   = make(map[string]RawChan)
+)
+
+func localChan(channelName string, bufferSize int) RawChan {
+	localChansMu.Lock()
+	defer localChansMu.Unlock()
+	if ch, ok := localChans[channelName]; ok {
+		return ch
+	}
+	ch := make(RawChan, bufferSize)
+	localChans[channelName] = ch
+	return ch
+}
+
+// pump relays every framed message Conn delivers onto raw, until the
+// connection is closed.
+func pump(conn Conn, raw RawChan) {
+	defer close(raw)
+	for {
+		b, err := conn.Receive()
+		if err != nil {
+			return
+		}
+		raw <- b
+	}
+}
+
+// GetDirectReadChannel dials location directly over transport (or
+// DefaultTransport, if transport is nil) and returns a RawChan fed by every
+// framed message the connection delivers, plus the Conn itself so callers
+// can read its transport-level metrics.
+func GetDirectReadChannel(channelName, location string, bufferSize int, transport Transport) (RawChan, Conn, error) {
+	if transport == nil {
+		transport = DefaultTransport
+	}
+	conn, err := transport.Dial(location)
+	if err != nil {
+		return nil, nil, err
+	}
+	raw := make(RawChan, bufferSize)
+	go pump(conn, raw)
+	return raw, conn, nil
+}
+
+// GetLocalReadChannel returns the RawChan registered under channelName
+// within this process - e.g. by a Channel dataset feeding values in, or by
+// GetLocalSendChannel - creating it if this is the first caller to ask for
+// it. transport is accepted for call-site uniformity with
+// GetDirectReadChannel, but same-process channels have no network hop to
+// select a transport for.
+func GetLocalReadChannel(channelName string, bufferSize int, transport Transport) (RawChan, Conn, error) {
+	return localChan(channelName, bufferSize), &localConn{name: channelName}, nil
+}
+
+// GetLocalSendChannel returns the RawChan registered under channelName
+// within this process, for a caller to write framed messages into; wg is
+// tracked by the caller to know when all writers have finished.
+func GetLocalSendChannel(channelName string, transport Transport, wg *sync.WaitGroup) (RawChan, Conn, error) {
+	return localChan(channelName, 0), &localConn{name: channelName}, nil
+}
@@ -0,0 +1,33 @@
+package netchan
+
+import (
+	"encoding/binary"
+	"io"
+)
+
+// writeFrame and readFrame give every stream-oriented Conn (tcpConn,
+// quicConn) message-boundary-preserving Send/Receive: without a length
+// prefix, a single Write from the sender can arrive as several partial
+// Reads, or several Writes can coalesce into one Read, silently splicing
+// unrelated messages together.
+func writeFrame(w io.Writer, b []byte) error {
+	var header [4]byte
+	binary.BigEndian.PutUint32(header[:], uint32(len(b)))
+	if _, err := w.Write(header[:]); err != nil {
+		return err
+	}
+	_, err := w.Write(b)
+	return err
+}
+
+func readFrame(r io.Reader) ([]byte, error) {
+	var header [4]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, err
+	}
+	b := make([]byte, binary.BigEndian.Uint32(header[:]))
+	if _, err := io.ReadFull(r, b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
@@ -0,0 +1,53 @@
+package netchan
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestWriteReadFrameRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	messages := [][]byte{[]byte("hello"), []byte(""), []byte("a longer second message")}
+
+	for _, m := range messages {
+		if err := writeFrame(&buf, m); err != nil {
+			t.Fatalf("writeFrame: %v", err)
+		}
+	}
+
+	for _, want := range messages {
+		got, err := readFrame(&buf)
+		if err != nil {
+			t.Fatalf("readFrame: %v", err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("readFrame = %q, want %q", got, want)
+		}
+	}
+}
+
+func TestWriteFrameDoesNotSpliceMessages(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeFrame(&buf, []byte("ab")); err != nil {
+		t.Fatalf("writeFrame: %v", err)
+	}
+	if err := writeFrame(&buf, []byte("cd")); err != nil {
+		t.Fatalf("writeFrame: %v", err)
+	}
+
+	first, err := readFrame(&buf)
+	if err != nil {
+		t.Fatalf("readFrame: %v", err)
+	}
+	if string(first) != "ab" {
+		t.Fatalf("first frame = %q, want %q", first, "ab")
+	}
+	second, err := readFrame(&buf)
+	if err != nil {
+		t.Fatalf("readFrame: %v", err)
+	}
+	if string(second) != "cd" {
+		t.Fatalf("second frame = %q, want %q", second, "cd")
+	}
+}
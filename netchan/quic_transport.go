@@ -0,0 +1,173 @@
+package netchan
+
+import (
+	"context"
+	"crypto/tls"
+	"sync"
+
+	quic "github.com/quic-go/quic-go"
+)
+
+// quicTransport multiplexes every channel for a given location over a
+// single QUIC connection, giving WAN deployments 0-RTT reconnection and
+// per-stream flow control without one slow channel head-of-line-blocking
+// the others: Dial caches one EarlyConnection per location and hands out a
+// new stream per channel, and Listen's accepted connections are likewise
+// read for as many streams as arrive instead of being accepted once and
+// dropped.
+type quicTransport struct {
+	tlsConf *tls.Config
+
+	mu    sync.Mutex
+	conns map[string]quic.EarlyConnection
+}
+
+func NewQUICTransport(tlsConf *tls.Config) Transport {
+	return &quicTransport{tlsConf: tlsConf, conns: make(map[string]quic.EarlyConnection)}
+}
+
+func (t *quicTransport) Name() string {
+	return "quic"
+}
+
+func (t *quicTransport) Dial(location string) (Conn, error) {
+	sess, err := t.session(location)
+	if err != nil {
+		return nil, err
+	}
+	stream, err := sess.OpenStreamSync(context.Background())
+	if err != nil {
+		// the cached connection died since it was last used - evict it and
+		// dial once more before giving up.
+		t.evict(location, sess)
+		sess, err = t.session(location)
+		if err != nil {
+			return nil, err
+		}
+		stream, err = sess.OpenStreamSync(context.Background())
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &quicConn{session: sess, stream: stream}, nil
+}
+
+// session returns the cached EarlyConnection for location, dialing and
+// caching a new one the first time a channel needs that location.
+func (t *quicTransport) session(location string) (quic.EarlyConnection, error) {
+	t.mu.Lock()
+	if sess, ok := t.conns[location]; ok {
+		t.mu.Unlock()
+		return sess, nil
+	}
+	t.mu.Unlock()
+
+	sess, err := quic.DialAddrEarly(context.Background(), location, t.tlsConf, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if existing, ok := t.conns[location]; ok {
+		// lost a race with a concurrent Dial to the same location - keep
+		// the connection already cached and drop this redundant one.
+		sess.CloseWithError(0, "")
+		return existing, nil
+	}
+	t.conns[location] = sess
+	return sess, nil
+}
+
+// evict drops sess from the cache if it's still the one cached for
+// location, so the next Dial redials instead of reusing a dead connection.
+func (t *quicTransport) evict(location string, sess quic.EarlyConnection) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.conns[location] == sess {
+		delete(t.conns, location)
+	}
+}
+
+func (t *quicTransport) Listen(location string) (Listener, error) {
+	ln, err := quic.ListenAddrEarly(location, t.tlsConf, nil)
+	if err != nil {
+		return nil, err
+	}
+	l := &quicListener{
+		ln:      ln,
+		streams: make(chan *quicConn),
+		errs:    make(chan error, 1),
+	}
+	go l.acceptConns()
+	return l, nil
+}
+
+type quicConn struct {
+	session quic.EarlyConnection
+	stream  quic.Stream
+}
+
+func (c *quicConn) Send(b []byte) error {
+	return writeFrame(c.stream, b)
+}
+
+func (c *quicConn) Receive() ([]byte, error) {
+	return readFrame(c.stream)
+}
+
+func (c *quicConn) Close() error {
+	return c.stream.Close()
+}
+
+func (c *quicConn) Metrics() TransportMetrics {
+	return TransportMetrics{
+		Transport: "quic",
+		RTT:       c.session.ConnectionState().RTTStats.SmoothedRTT(),
+	}
+}
+
+// quicListener accepts QUIC connections in the background and serves every
+// stream opened on each one as its own Conn, so N channels dialed against
+// one location over one connection become N Accept() results here instead
+// of only the first stream ever being handed back.
+type quicListener struct {
+	ln *quic.EarlyListener
+
+	streams chan *quicConn
+	errs    chan error
+}
+
+func (l *quicListener) acceptConns() {
+	for {
+		sess, err := l.ln.Accept(context.Background())
+		if err != nil {
+			l.errs <- err
+			return
+		}
+		go l.acceptStreams(sess)
+	}
+}
+
+func (l *quicListener) acceptStreams(sess quic.EarlyConnection) {
+	for {
+		stream, err := sess.AcceptStream(context.Background())
+		if err != nil {
+			return
+		}
+		l.streams <- &quicConn{session: sess, stream: stream}
+	}
+}
+
+func (l *quicListener) Accept() (Conn, error) {
+	select {
+	case c := <-l.streams:
+		return c, nil
+	case err := <-l.errs:
+		return nil, err
+	}
+}
+
+func (l *quicListener) Close() error {
+	return l.ln.Close()
+}
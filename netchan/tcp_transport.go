@@ -0,0 +1,71 @@
+package netchan
+
+import (
+	"net"
+)
+
+// tcpTransport is the original, always-available transport: one TCP
+// connection per channel, no multiplexing.
+type tcpTransport struct{}
+
+func NewTCPTransport() Transport {
+	return &tcpTransport{}
+}
+
+func (t *tcpTransport) Name() string {
+	return "tcp"
+}
+
+func (t *tcpTransport) Dial(location string) (Conn, error) {
+	conn, err := net.Dial("tcp", location)
+	if err != nil {
+		return nil, err
+	}
+	return &tcpConn{conn: conn}, nil
+}
+
+func (t *tcpTransport) Listen(location string) (Listener, error) {
+	ln, err := net.Listen("tcp", location)
+	if err != nil {
+		return nil, err
+	}
+	return &tcpListener{ln: ln}, nil
+}
+
+type tcpConn struct {
+	conn net.Conn
+}
+
+func (c *tcpConn) Send(b []byte) error {
+	return writeFrame(c.conn, b)
+}
+
+func (c *tcpConn) Receive() ([]byte, error) {
+	return readFrame(c.conn)
+}
+
+func (c *tcpConn) Close() error {
+	return c.conn.Close()
+}
+
+func (c *tcpConn) Metrics() TransportMetrics {
+	// Plain TCP exposes no per-connection RTT/retransmit counters through
+	// the standard library, so only identify the transport in use.
+	return TransportMetrics{Transport: "tcp"}
+}
+
+type tcpListener struct {
+	ln net.Listener
+}
+
+func (l *tcpListener) Accept() (Conn, error) {
+	conn, err := l.ln.Accept()
+	if err != nil {
+		return nil, err
+	}
+	return &tcpConn{conn: conn}, nil
+}
+
+func (l *tcpListener) Close() error {
+	return l.ln.Close()
+}
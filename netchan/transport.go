@@ -0,0 +1,40 @@
+package netchan
+
+import "time"
+
+// Transport abstracts the underlying connection a netchan is carried over,
+// so a flow can pick TCP for simple same-datacenter deployments or a
+// multiplexed, loss-tolerant transport like QUIC for high-latency or lossy
+// WAN deployments, without changing any channel-creation call site.
+type Transport interface {
+	Name() string
+	Dial(location string) (Conn, error)
+	Listen(location string) (Listener, error)
+}
+
+// Conn is the minimal connection surface the direct/local channel plumbing
+// needs from a Transport, plus access to the metrics it collects.
+type Conn interface {
+	Send(b []byte) error
+	Receive() ([]byte, error)
+	Close() error
+	Metrics() TransportMetrics
+}
+
+// Listener accepts incoming Conns for a Transport.
+type Listener interface {
+	Accept() (Conn, error)
+	Close() error
+}
+
+// TransportMetrics is reported alongside a channel's existing byte/message
+// counters so operators can see connection-level health per transport.
+type TransportMetrics struct {
+	Transport   string
+	RTT         time.Duration
+	Retransmits int64
+}
+
+// DefaultTransport is used wherever a TaskOption does not select one
+// explicitly, preserving the plain TCP behavior existing flows rely on.
+var DefaultTransport Transport = NewTCPTransport()